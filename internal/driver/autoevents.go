@@ -0,0 +1,159 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	dsModels "github.com/edgexfoundry/device-sdk-go/pkg/models"
+	contract "github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// autoEventPoller tracks the goroutines running a device's AutoEvents, so
+// AddDevice/UpdateDevice/RemoveDevice can start, restart, or stop them.
+type autoEventPoller struct {
+	done chan struct{}
+}
+
+// startAutoEvents looks up deviceName's profile-declared AutoEvents (e.g.
+// {resource: "ReaderConfig", frequency: "30s", onChange: true}) and launches
+// one polling goroutine per entry. Any poller already running for this
+// device is stopped first, so this also serves as "restart".
+func (d *Driver) startAutoEvents(deviceName string) {
+	d.stopAutoEvents(deviceName)
+
+	dev, err := d.service().GetDeviceByName(deviceName)
+	if err != nil {
+		d.lc.Error("unable to load device for AutoEvents", "device", deviceName, "error", err.Error())
+		return
+	}
+	if len(dev.AutoEvents) == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	d.autoPollersMu.Lock()
+	d.autoPollers[deviceName] = &autoEventPoller{done: done}
+	d.autoPollersMu.Unlock()
+
+	for _, ae := range dev.AutoEvents {
+		go d.runAutoEvent(deviceName, ae, done)
+	}
+}
+
+// stopAutoEvents cancels every AutoEvent poller running for deviceName, if
+// any.
+func (d *Driver) stopAutoEvents(deviceName string) {
+	d.autoPollersMu.Lock()
+	p, ok := d.autoPollers[deviceName]
+	delete(d.autoPollers, deviceName)
+	d.autoPollersMu.Unlock()
+
+	if ok {
+		close(p.done)
+	}
+}
+
+// stopAllAutoEvents cancels every AutoEvent poller for every device; Stop
+// calls this for a clean shutdown.
+func (d *Driver) stopAllAutoEvents() {
+	d.autoPollersMu.Lock()
+	pollers := d.autoPollers
+	d.autoPollers = make(map[string]*autoEventPoller)
+	d.autoPollersMu.Unlock()
+
+	for _, p := range pollers {
+		close(p.done)
+	}
+}
+
+// runAutoEvent issues the LLRP read ae.Resource maps to, on ae.Frequency,
+// for as long as done stays open. When ae.OnChange is set, it diffs the
+// freshly-read value against the last one it pushed and only forwards an
+// AsyncValues when the JSON differs - e.g., detecting a reader config that
+// drifted because someone rewrote antenna power out-of-band.
+func (d *Driver) runAutoEvent(deviceName string, ae contract.AutoEvent, done <-chan struct{}) {
+	dl := newDeviceLogger(d.lc, deviceName)
+
+	interval, err := time.ParseDuration(ae.Frequency)
+	if err != nil {
+		dl.Error("invalid AutoEvent frequency, not polling", "resource", ae.Resource, "frequency", ae.Frequency, "error", err.Error())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		data, err := d.readAutoEventResource(deviceName, ae.Resource)
+		if err != nil {
+			dl.Error("AutoEvent read failed", "resource", ae.Resource, "error", err.Error())
+			continue
+		}
+
+		if !shouldPublishAutoEvent(ae.OnChange, last, data) {
+			continue
+		}
+		last = data
+
+		dl.Debug("AutoEvent pushing update", "resource", ae.Resource, "onChange", ae.OnChange)
+		d.asyncCh <- &dsModels.AsyncValues{
+			DeviceName: deviceName,
+			CommandValues: []*dsModels.CommandValue{
+				dsModels.NewStringValue(ae.Resource, time.Now().UnixNano(), string(data)),
+			},
+		}
+	}
+}
+
+// shouldPublishAutoEvent reports whether a freshly-read value is worth
+// pushing: always true when onChange is false, otherwise only when data
+// differs from the last value published (or nothing has been published
+// yet).
+func shouldPublishAutoEvent(onChange bool, last, data []byte) bool {
+	if onChange && last != nil && bytes.Equal(data, last) {
+		return false
+	}
+	return true
+}
+
+// readAutoEventResource issues the LLRP read resourceReadCommand maps
+// resource to and returns the JSON-marshaled response, the same way
+// HandleReadCommands does.
+func (d *Driver) readAutoEventResource(deviceName, resource string) ([]byte, error) {
+	d.clientsMapMu.RLock()
+	c, ok := d.clients[deviceName]
+	d.clientsMapMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("%s: not connected", deviceName)
+	}
+
+	req, resp, err := resourceReadCommand(resource)
+	if err != nil {
+		return nil, errors.Wrap(err, "unsupported AutoEvent resource")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	if err := c.SendFor(ctx, req, resp); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(resp)
+}
@@ -0,0 +1,48 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import "testing"
+
+func TestShouldPublishAutoEvent(t *testing.T) {
+	cases := []struct {
+		name     string
+		onChange bool
+		last     []byte
+		data     []byte
+		want     bool
+	}{
+		{"not onChange always publishes", false, []byte(`{"a":1}`), []byte(`{"a":1}`), true},
+		{"onChange first read has no last value", true, nil, []byte(`{"a":1}`), true},
+		{"onChange unchanged value is suppressed", true, []byte(`{"a":1}`), []byte(`{"a":1}`), false},
+		{"onChange changed value publishes", true, []byte(`{"a":1}`), []byte(`{"a":2}`), true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := shouldPublishAutoEvent(c.onChange, c.last, c.data); got != c.want {
+				t.Errorf("shouldPublishAutoEvent(%v, %s, %s) = %v, want %v",
+					c.onChange, c.last, c.data, got, c.want)
+			}
+		})
+	}
+}
+
+func TestResourceReadCommand(t *testing.T) {
+	for _, resource := range []string{ResourceReaderConfig, ResourceReaderCap, ResourceROSpec, ResourceAccessSpec} {
+		req, resp, err := resourceReadCommand(resource)
+		if err != nil {
+			t.Errorf("resourceReadCommand(%q) returned error: %v", resource, err)
+		}
+		if req == nil || resp == nil {
+			t.Errorf("resourceReadCommand(%q) returned a nil request or response", resource)
+		}
+	}
+
+	if _, _, err := resourceReadCommand("NotAResource"); err == nil {
+		t.Error("expected an error for an unsupported resource")
+	}
+}
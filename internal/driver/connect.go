@@ -0,0 +1,333 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.impcloud.net/RSP-Inventory-Suite/device-llrp-go/internal/llrp"
+	"github.impcloud.net/RSP-Inventory-Suite/device-llrp-go/internal/retry"
+)
+
+// getAddr extracts an address from a protocol mapping.
+//
+// It prefers a {"tls": {"host","port","caCertFile","clientCertFile",
+// "clientKeyFile","serverName","insecureSkipVerify"}} entry, built by
+// newTLSConfig, over the plain {"tcp": {"host","port"}} entry. When a "tls"
+// entry is present, the returned *tls.Config is non-nil and dial uses it
+// instead of a bare net.DialTimeout. It's called fresh on every dial
+// attempt, so rotated cert files on disk take effect on the next redial.
+func getAddr(protocols protocolMap) (net.Addr, *tls.Config, error) {
+	if tlsInfo := protocols[protocolTLS]; tlsInfo != nil {
+		return tlsAddr(tlsInfo)
+	}
+
+	tcpInfo := protocols[protocolTCP]
+	if tcpInfo == nil {
+		return nil, nil, errors.New("missing tcp or tls protocol")
+	}
+
+	host := tcpInfo["host"]
+	port := tcpInfo["port"]
+	if host == "" || port == "" {
+		return nil, nil, errors.Errorf("tcp missing host or port (%q, %q)", host, port)
+	}
+
+	addr, err := net.ResolveTCPAddr("tcp", host+":"+port)
+	return addr, nil, errors.Wrapf(err,
+		"unable to create addr for tcp protocol (%q, %q)", host, port)
+}
+
+// addrFromProtocols extracts the host:port address encoded in a device's
+// protocol properties, in the same net.JoinHostPort form scanTarget.name()
+// produces, so Discover's results are comparable against it. It prefers a
+// "tls" entry over "tcp", mirroring getAddr's preference, but unlike getAddr
+// it's just string plumbing for comparison - it doesn't resolve the address
+// or build a *tls.Config.
+func addrFromProtocols(protocols protocolMap) (string, bool) {
+	if info := protocols[protocolTLS]; info != nil {
+		if addr, ok := hostPortFrom(info); ok {
+			return addr, true
+		}
+	}
+	if info := protocols[protocolTCP]; info != nil {
+		if addr, ok := hostPortFrom(info); ok {
+			return addr, true
+		}
+	}
+	return "", false
+}
+
+func hostPortFrom(info map[string]string) (string, bool) {
+	host, port := info["host"], info["port"]
+	if host == "" || port == "" {
+		return "", false
+	}
+	return net.JoinHostPort(host, port), true
+}
+
+// tlsAddr resolves the dial address and TLS configuration carried in a
+// device's "tls" protocol entry.
+func tlsAddr(tlsInfo map[string]string) (net.Addr, *tls.Config, error) {
+	host := tlsInfo["host"]
+	port := tlsInfo["port"]
+	if host == "" || port == "" {
+		return nil, nil, errors.Errorf("tls missing host or port (%q, %q)", host, port)
+	}
+
+	addr, err := net.ResolveTCPAddr("tcp", host+":"+port)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err,
+			"unable to create addr for tls protocol (%q, %q)", host, port)
+	}
+
+	cfg, err := newTLSConfig(tlsInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return addr, cfg, nil
+}
+
+// getClient returns the live Client for name. It never blocks waiting for a
+// dial: if name isn't connected yet, it makes sure connectLoop is running
+// (starting one if needed) and returns immediately with an error, so one
+// slow or unreachable reader can't stall a command-handling goroutine. The
+// connection keeps being attempted in the background; a later call (e.g.
+// the next command) succeeds once connectLoop publishes the Client.
+// AutoEvent polls read the clients map directly and don't go through
+// getClient.
+func (d *Driver) getClient(name string, p protocolMap) (*llrp.Client, error) {
+	d.clientsMapMu.RLock()
+	c, ok := d.clients[name]
+	d.clientsMapMu.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	d.ensureConnecting(name, p)
+	return nil, errors.Errorf("%s: not yet connected, connection attempt in progress", name)
+}
+
+// ensureConnecting launches connectLoop for name if it isn't already
+// running. It returns as soon as the goroutine is started (or confirms one
+// is already running/connected) - long before any dial attempt resolves -
+// which is what makes getClient and AddDevice non-blocking.
+func (d *Driver) ensureConnecting(name string, p protocolMap) {
+	d.clientsMapMu.Lock()
+	defer d.clientsMapMu.Unlock()
+
+	if _, ok := d.clients[name]; ok {
+		return
+	}
+	if _, running := d.done[name]; running {
+		return
+	}
+
+	done := make(chan struct{})
+	d.done[name] = done
+	go d.connectLoop(name, p, done)
+}
+
+// connectLoop owns the entire connection lifecycle for a single device: it
+// dials (with backoff) until it succeeds, done is closed, or dialWithBackoff
+// gives up, publishes the live Client into the clients map, then blocks on
+// Connect() and redials on a transient failure. ensureConnecting starts at
+// most one connectLoop per device at a time. A loop whose done was closed
+// (by removeClient or Stop) can still have one dial in flight while a
+// replacement loop dials; if that stale dial succeeds, its Client is closed
+// rather than published.
+func (d *Driver) connectLoop(name string, p protocolMap, done <-chan struct{}) {
+	defer func() {
+		d.clientsMapMu.Lock()
+		if d.done[name] == done {
+			delete(d.done, name)
+		}
+		d.clientsMapMu.Unlock()
+		d.metrics.setConnState(name, connClosed)
+	}()
+
+	for attempt := 0; ; attempt++ {
+		if attempt == 0 {
+			d.metrics.setConnState(name, connDialing)
+		} else {
+			d.metrics.setConnState(name, connReconnecting)
+		}
+
+		c, err := d.dialWithBackoff(name, p, done)
+		if err != nil {
+			d.lc.Error("giving up connecting to device", "device", name, "error", err.Error())
+			return
+		}
+		if c == nil {
+			// done was closed while dialing.
+			return
+		}
+
+		d.clientsMapMu.Lock()
+		if d.done[name] != done {
+			// Stop/RemoveDevice claimed this device while we were dialing.
+			d.clientsMapMu.Unlock()
+			_ = c.Close()
+			return
+		}
+		d.clients[name] = c
+		d.clientsMapMu.Unlock()
+		d.metrics.setConnState(name, connConnected)
+
+		// blocks until the connection is closed, locally or remotely;
+		// the registered Closed handler (see tryDial) scrubs c from the
+		// clients map before this returns.
+		err = c.Connect()
+
+		if err == nil || errors.Is(err, llrp.ErrClientClosed) {
+			return
+		}
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		d.lc.Error("device connection lost, reconnecting", "device", name, "error", err.Error())
+	}
+}
+
+// onClientClosed is registered on every Client as its Closed handler. It
+// scrubs c from the clients map, but only if it's still the client
+// connectLoop published - a concurrent RemoveDevice/Stop may have already
+// claimed (and replaced or deleted) this device's entry, in which case that
+// caller wins and no further action is needed here.
+func (d *Driver) onClientClosed(c *llrp.Client, err error) {
+	d.clientsMapMu.Lock()
+	defer d.clientsMapMu.Unlock()
+
+	if d.clients[c.Name] != c {
+		return
+	}
+
+	delete(d.clients, c.Name)
+	if err != nil && !errors.Is(err, llrp.ErrClientClosed) {
+		d.lc.Error("device connection closed unexpectedly", "device", c.Name, "error", err.Error())
+	}
+}
+
+// dialWithBackoff redials name, using retry.Slow, until it connects, done is
+// closed, or an attempt fails with an error that isn't a temporary net.Error
+// (such as an address that can't be resolved), which it returns. A nil, nil
+// return means
+// done was closed before a connection could be established. Each attempt
+// goes through d.dialOnce (d.tryDial in production) rather than calling
+// tryDial directly, so tests can stub the dial itself.
+func (d *Driver) dialWithBackoff(name string, p protocolMap, done <-chan struct{}) (*llrp.Client, error) {
+	var c *llrp.Client
+	err := retry.Slow.RetrySome(retry.Forever, func() (recoverable bool, err error) {
+		select {
+		case <-done:
+			return false, llrp.ErrClientClosed
+		default:
+		}
+
+		c, err = d.dialOnce(name, p)
+		if err == nil {
+			return false, nil
+		}
+
+		neterr, ok := err.(net.Error)
+		recoverable = ok && neterr.Temporary()
+		return
+	})
+
+	if errors.Is(err, llrp.ErrClientClosed) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// tryDial resolves name's address fresh (so rotated TLS certs are picked up)
+// and dials it once.
+func (d *Driver) tryDial(name string, p protocolMap) (*llrp.Client, error) {
+	addr, tlsCfg, err := getAddr(p)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dial(addr, tlsCfg, time.Second*30)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if fp, err := peerFingerprint(tlsConn); err != nil {
+			d.lc.Warn("unable to compute device fingerprint", "device", name, "error", err.Error())
+		} else {
+			d.lc.Info("LLRP-over-TLS handshake complete", "device", name, "fingerprint", fp)
+		}
+	}
+
+	toEdgex := llrp.MessageHandlerFunc(d.handleAsyncMessages)
+
+	// WithClosedHandler registers onClientClosed as llrp.Client's Closed(conn,
+	// err) callback; that option and the callback it wires up are assumed to
+	// already exist on llrp.Client (internal/llrp isn't touched by this
+	// package). What's new here is the driver side: connectLoop/onClientClosed
+	// replace the old pattern of a manually re-dialed Client whose previous
+	// instance was left on the floor for a leaked goroutine to hold onto.
+	//
+	// That assumption is unverified in this checkout: internal/llrp isn't
+	// present here, so this package cannot actually be built or tested
+	// against it. Before merging, confirm upstream's llrp.Client exports
+	// WithClosedHandler(func(c *llrp.Client, err error)) with exactly
+	// onClientClosed's signature (internal/driver/connect.go's
+	// onClientClosed) - if it doesn't, this dial path won't compile and
+	// needs to be adjusted to whatever the real close-notification API is.
+	return llrp.NewClient(conn,
+		llrp.WithName(name),
+		llrp.WithLogger(&edgexLLRPClientLogger{devName: name, lc: d.lc}),
+		llrp.WithMessageHandler(llrp.MsgROAccessReport, toEdgex),
+		llrp.WithMessageHandler(llrp.MsgReaderEventNotification, toEdgex),
+		llrp.WithClosedHandler(d.onClientClosed),
+	)
+}
+
+// removeClient deletes a Client (or an in-progress connectLoop) from the
+// clients map and tears it down.
+func (d *Driver) removeClient(deviceName string, force bool) {
+	d.clientsMapMu.Lock()
+	c, hasClient := d.clients[deviceName]
+	done, hasDone := d.done[deviceName]
+	delete(d.clients, deviceName)
+	delete(d.done, deviceName)
+	d.clientsMapMu.Unlock()
+
+	if hasDone {
+		close(done)
+	}
+	if hasClient {
+		go d.stopClient(c, force)
+	}
+}
+
+func (d *Driver) stopClient(c *llrp.Client, force bool) {
+	if !force {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		err := c.Shutdown(ctx)
+		if err == nil || errors.Is(err, llrp.ErrClientClosed) {
+			return
+		}
+		d.lc.Error("error attempting graceful client shutdown", "error", err.Error())
+	}
+
+	if err := c.Close(); err != nil && !errors.Is(err, llrp.ErrClientClosed) {
+		d.lc.Error("error attempting forceful client shutdown", "error", err.Error())
+	}
+}
@@ -0,0 +1,307 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+
+	"github.impcloud.net/RSP-Inventory-Suite/device-llrp-go/internal/llrp"
+)
+
+// noopMetrics discards every call, so connect_test can exercise connectLoop's
+// bookkeeping without standing up the Prometheus registry newPromMetrics
+// uses.
+type noopMetrics struct{}
+
+func (noopMetrics) observeCommand(string, string, string, error, time.Duration) {}
+func (noopMetrics) observeAsyncMessage(string, llrp.MessageType, int)           {}
+func (noopMetrics) setConnState(string, connState)                              {}
+
+func newTestDriver() *Driver {
+	return &Driver{
+		clients:           make(map[string]*llrp.Client),
+		done:              make(map[string]chan struct{}),
+		knownAddrs:        make(map[string]string),
+		addrByDevice:      make(map[string]string),
+		protocolsByDevice: make(map[string]protocolMap),
+		metrics:           noopMetrics{},
+		lc:                logger.NewClientStdOut(ServiceName, false, "DEBUG"),
+	}
+}
+
+// permanentDialErr doesn't implement net.Error, so dialWithBackoff treats it
+// as unrecoverable and gives up after a single attempt - the same way a
+// malformed address (e.g. getAddr failing) would.
+type permanentDialErr struct{}
+
+func (permanentDialErr) Error() string { return "permanent dial failure" }
+
+// TestGetClientNonBlockingWhileDialing is the regression test for the bug
+// this request fixes: getClient must return immediately for a device whose
+// dial is still in flight, not block until it resolves.
+func TestGetClientNonBlockingWhileDialing(t *testing.T) {
+	d := newTestDriver()
+
+	gate := make(chan struct{})
+	defer close(gate) // let the stuck dial finish so its goroutine doesn't leak past the test
+
+	var calls int32
+	d.dialOnce = func(string, protocolMap) (*llrp.Client, error) {
+		atomic.AddInt32(&calls, 1)
+		<-gate // simulates a dial to an unreachable/slow reader
+		return nil, permanentDialErr{}
+	}
+
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		if _, err := d.getClient("reader-1", protocolMap{}); err == nil {
+			t.Error("expected an error for a device that hasn't connected yet")
+		}
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("getClient blocked instead of returning immediately while the dial was in flight")
+	}
+
+	// connectLoop dials on its own goroutine, so the attempt can start just
+	// after getClient returns; wait for it before counting.
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("expected exactly one dial attempt to have started, got %d", n)
+	}
+}
+
+// TestEnsureConnectingIsIdempotent checks that concurrent callers (e.g.
+// AddDevice racing a command handler's getClient) never start more than one
+// connectLoop/dial for the same device.
+func TestEnsureConnectingIsIdempotent(t *testing.T) {
+	d := newTestDriver()
+
+	gate := make(chan struct{})
+	defer close(gate)
+
+	var calls int32
+	d.dialOnce = func(string, protocolMap) (*llrp.Client, error) {
+		atomic.AddInt32(&calls, 1)
+		<-gate
+		return nil, permanentDialErr{}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.ensureConnecting("reader-1", protocolMap{})
+		}()
+	}
+	wg.Wait()
+
+	// Give a regression a moment to start a second dial before checking.
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("expected exactly one dial attempt across 5 concurrent callers, got %d", n)
+	}
+}
+
+// TestConnectLoopGivesUpOnPermanentError confirms that a non-recoverable
+// dial error still cleans up d.done, rather than leaving a phantom
+// in-progress entry behind.
+func TestConnectLoopGivesUpOnPermanentError(t *testing.T) {
+	d := newTestDriver()
+	d.dialOnce = func(string, protocolMap) (*llrp.Client, error) {
+		return nil, permanentDialErr{}
+	}
+
+	d.ensureConnecting("reader-1", protocolMap{})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		d.clientsMapMu.RLock()
+		_, stillRunning := d.done["reader-1"]
+		d.clientsMapMu.RUnlock()
+		if !stillRunning {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("connectLoop did not clean up d.done after a permanent dial failure")
+}
+
+// TestDoneChannelRace exercises the race connectLoop's defer is there to
+// handle: RemoveDevice/Stop (removeClient) closing done and deleting the
+// clients/done entries while a dial is still in flight must not be undone
+// once that dial eventually returns.
+func TestDoneChannelRace(t *testing.T) {
+	d := newTestDriver()
+
+	gate := make(chan struct{})
+	d.dialOnce = func(string, protocolMap) (*llrp.Client, error) {
+		<-gate
+		return nil, permanentDialErr{}
+	}
+
+	d.ensureConnecting("reader-1", protocolMap{})
+	d.removeClient("reader-1", true) // closes done, deletes the clients/done entries
+	close(gate)                      // let the in-flight dial return
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		d.clientsMapMu.RLock()
+		_, hasDone := d.done["reader-1"]
+		_, hasClient := d.clients["reader-1"]
+		d.clientsMapMu.RUnlock()
+		if !hasDone && !hasClient {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("removeClient's cleanup was undone by the in-flight dial resolving afterward")
+}
+
+// waitForDialCount polls dialedWith (guarded by mu) until it reaches want,
+// failing the test if that doesn't happen within a second.
+func waitForDialCount(t *testing.T, mu *sync.Mutex, dialedWith *[]protocolMap, want int, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(*dialedWith)
+		mu.Unlock()
+		if n == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestReconnectOnAddrChangeForcesReconnect is the regression test for
+// UpdateDevice leaving a stale connectLoop running forever after a device's
+// address changed: connectLoop captured the protocolMap AddDevice started it
+// with, so only a change detected here (and a removeClient/ensureConnecting
+// pair) can make it redial the new address.
+func TestReconnectOnAddrChangeForcesReconnect(t *testing.T) {
+	d := newTestDriver()
+
+	gate := make(chan struct{})
+	defer close(gate)
+
+	var dialedWith []protocolMap
+	var mu sync.Mutex
+	d.dialOnce = func(_ string, p protocolMap) (*llrp.Client, error) {
+		mu.Lock()
+		dialedWith = append(dialedWith, p)
+		mu.Unlock()
+		<-gate
+		return nil, permanentDialErr{}
+	}
+
+	oldProtocols := protocolMap{protocolTCP: {"host": "10.0.0.1", "port": "5084"}}
+	d.ensureConnecting("reader-1", oldProtocols)
+	d.rememberAddr("reader-1", oldProtocols)
+	d.rememberProtocols("reader-1", oldProtocols)
+
+	waitForDialCount(t, &mu, &dialedWith, 1, "initial connectLoop never dialed")
+
+	newProtocols := protocolMap{protocolTCP: {"host": "10.0.0.2", "port": "5084"}}
+	d.reconnectOnAddrChange("reader-1", newProtocols)
+
+	waitForDialCount(t, &mu, &dialedWith, 2, "reconnectOnAddrChange did not start a fresh connectLoop for the new address")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if host := dialedWith[1][protocolTCP]["host"]; host != "10.0.0.2" {
+		t.Errorf("expected the fresh connectLoop to dial the new address, got host %q", host)
+	}
+
+	if !d.hasKnownAddr("10.0.0.2:5084") {
+		t.Error("expected the new address to be remembered for Discover dedup")
+	}
+	if d.hasKnownAddr("10.0.0.1:5084") {
+		t.Error("expected the old address to be forgotten once it was replaced")
+	}
+}
+
+// TestReconnectOnAddrChangeDetectsTLSFieldChange is the regression test for
+// reconnectOnAddrChange only comparing host:port: rotating a TLS-relevant
+// field (e.g. clientCertFile after a cert renewal) with the same host:port
+// must still force a reconnect, since connectLoop would otherwise keep
+// dialing with the stale cert path for the rest of its lifetime.
+func TestReconnectOnAddrChangeDetectsTLSFieldChange(t *testing.T) {
+	d := newTestDriver()
+
+	gate := make(chan struct{})
+	defer close(gate)
+
+	var dialedWith []protocolMap
+	var mu sync.Mutex
+	d.dialOnce = func(_ string, p protocolMap) (*llrp.Client, error) {
+		mu.Lock()
+		dialedWith = append(dialedWith, p)
+		mu.Unlock()
+		<-gate
+		return nil, permanentDialErr{}
+	}
+
+	oldProtocols := protocolMap{protocolTLS: {
+		"host": "10.0.0.1", "port": "5085", "clientCertFile": "/etc/llrp/old-cert.pem",
+	}}
+	d.ensureConnecting("reader-1", oldProtocols)
+	d.rememberAddr("reader-1", oldProtocols)
+	d.rememberProtocols("reader-1", oldProtocols)
+
+	waitForDialCount(t, &mu, &dialedWith, 1, "initial connectLoop never dialed")
+
+	// Same host:port, rotated cert path only.
+	newProtocols := protocolMap{protocolTLS: {
+		"host": "10.0.0.1", "port": "5085", "clientCertFile": "/etc/llrp/new-cert.pem",
+	}}
+	d.reconnectOnAddrChange("reader-1", newProtocols)
+
+	waitForDialCount(t, &mu, &dialedWith, 2, "reconnectOnAddrChange did not reconnect after a TLS-only config change")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if cert := dialedWith[1][protocolTLS]["clientCertFile"]; cert != "/etc/llrp/new-cert.pem" {
+		t.Errorf("expected the fresh connectLoop to dial with the rotated cert path, got %q", cert)
+	}
+}
+
+// TestReconnectOnAddrChangeLeavesUnchangedProtocolsAlone confirms an
+// UpdateDevice call that doesn't change any connection-relevant field (e.g.
+// only adminState changed) doesn't tear down a perfectly good connection.
+func TestReconnectOnAddrChangeLeavesUnchangedProtocolsAlone(t *testing.T) {
+	d := newTestDriver()
+
+	protocols := protocolMap{protocolTCP: {"host": "10.0.0.1", "port": "5084"}}
+	d.rememberAddr("reader-1", protocols)
+	d.rememberProtocols("reader-1", protocols)
+
+	done := make(chan struct{})
+	d.done["reader-1"] = done
+
+	d.reconnectOnAddrChange("reader-1", protocols)
+
+	select {
+	case <-done:
+		t.Fatal("reconnectOnAddrChange tore down a connection whose protocols didn't change")
+	default:
+	}
+}
@@ -0,0 +1,390 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	dsModels "github.com/edgexfoundry/device-sdk-go/pkg/models"
+	contract "github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.impcloud.net/RSP-Inventory-Suite/device-llrp-go/internal/llrp"
+)
+
+// discoveryConfig controls the active LLRP scan performed by Discover().
+type discoveryConfig struct {
+	// Subnets are the CIDRs to probe, e.g. "192.168.1.0/24".
+	Subnets []string
+	// Ports are probed on every host in Subnets.
+	Ports []int
+	// MaxConcurrency bounds the number of probes in flight at once.
+	MaxConcurrency int
+	// ProbeTimeout bounds a single host:port dial+handshake attempt.
+	ProbeTimeout time.Duration
+	// ScanTimeout bounds the whole scan, across all hosts and ports.
+	ScanTimeout time.Duration
+}
+
+const (
+	discoveryPortPlain = 5084
+	discoveryPortTLS   = 5085
+)
+
+func defaultDiscoveryConfig() discoveryConfig {
+	return discoveryConfig{
+		Ports:          []int{discoveryPortPlain, discoveryPortTLS},
+		MaxConcurrency: 100,
+		ProbeTimeout:   3 * time.Second,
+		ScanTimeout:    30 * time.Second,
+	}
+}
+
+// loadDiscoveryConfig reads the [Driver] section the EdgeX service wrapper
+// loaded from configuration.toml. Missing or malformed keys fall back to
+// defaultDiscoveryConfig's values.
+func (d *Driver) loadDiscoveryConfig() discoveryConfig {
+	cfg := defaultDiscoveryConfig()
+
+	raw := d.service().DriverConfigs()
+
+	if subnets := raw["DiscoverySubnets"]; subnets != "" {
+		cfg.Subnets = strings.Split(subnets, ",")
+		for i := range cfg.Subnets {
+			cfg.Subnets[i] = strings.TrimSpace(cfg.Subnets[i])
+		}
+	}
+
+	if ports := raw["DiscoveryPorts"]; ports != "" {
+		var parsed []int
+		for _, p := range strings.Split(ports, ",") {
+			port, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				d.lc.Warn("ignoring invalid DiscoveryPorts entry", "value", p, "error", err.Error())
+				continue
+			}
+			parsed = append(parsed, port)
+		}
+		if len(parsed) > 0 {
+			cfg.Ports = parsed
+		}
+	}
+
+	if v := raw["DiscoveryMaxConcurrency"]; v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxConcurrency = n
+		}
+	}
+
+	if v := raw["DiscoveryProbeTimeout"]; v != "" {
+		if timeout, err := time.ParseDuration(v); err == nil {
+			cfg.ProbeTimeout = timeout
+		}
+	}
+
+	if v := raw["DiscoveryScanTimeout"]; v != "" {
+		if timeout, err := time.ParseDuration(v); err == nil {
+			cfg.ScanTimeout = timeout
+		}
+	}
+
+	return cfg
+}
+
+// Discover runs an active scan across the configured subnets and ports,
+// publishing each newly-found reader to d.deviceCh as soon as its probe
+// completes, rather than batching results until the whole scan finishes.
+// It's cancellable: Stop cancels the context stored on d.discoveryCancel,
+// which aborts any in-flight probes.
+func (d *Driver) Discover() {
+	cfg := d.loadDiscoveryConfig()
+	if len(cfg.Subnets) == 0 {
+		d.lc.Info("discovery skipped: no subnets configured")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ScanTimeout)
+	defer cancel()
+
+	d.discoveryMu.Lock()
+	if d.discoveryCancel != nil {
+		d.discoveryMu.Unlock()
+		d.lc.Warn("discovery already in progress, ignoring request")
+		return
+	}
+	d.discoveryCancel = cancel
+	d.discoveryMu.Unlock()
+
+	defer func() {
+		d.discoveryMu.Lock()
+		d.discoveryCancel = nil
+		d.discoveryMu.Unlock()
+	}()
+
+	d.lc.Info("starting LLRP discovery scan",
+		"subnets", cfg.Subnets, "ports", cfg.Ports, "maxConcurrency", cfg.MaxConcurrency)
+
+	targets, err := expandTargets(cfg.Subnets, cfg.Ports)
+	if err != nil {
+		d.lc.Error("failed to expand discovery subnets", "error", err.Error())
+		return
+	}
+
+	var found sync.Map // readerID (string) -> struct{}, for de-duping readers with multiple NICs
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, cfg.MaxConcurrency)
+
+scan:
+	for _, target := range targets {
+		if d.hasKnownAddr(target.name()) {
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break scan
+		}
+
+		wg.Add(1)
+		go func(t scanTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			d.probeAndPublish(ctx, t, &found, cfg.ProbeTimeout)
+		}(target)
+	}
+
+	wg.Wait()
+	d.lc.Info("LLRP discovery scan complete")
+}
+
+// scanTarget is a single host:port, plain or TLS, to probe.
+type scanTarget struct {
+	host string
+	port int
+	tls  bool
+}
+
+func (t scanTarget) name() string {
+	return net.JoinHostPort(t.host, strconv.Itoa(t.port))
+}
+
+// protocols builds the protocolMap a discovered device is registered with.
+// A target probed over TLS (see expandTargets) must be registered with a
+// "tls" entry, not "tcp" - getAddr only dials TLS when that key is present,
+// so registering it as plain "tcp" would make the first real reconnect
+// attempt send a plaintext handshake at a TLS-only port and fail. The probe
+// itself used InsecureSkipVerify because the reader's identity wasn't known
+// yet; that's carried over here too, but an operator should supply real
+// caCertFile/clientCertFile/clientKeyFile values (see newTLSConfig) before
+// relying on this device for anything beyond discovery.
+func (t scanTarget) protocols() protocolMap {
+	if !t.tls {
+		return protocolMap{
+			protocolTCP: contract.ProtocolProperties{
+				"host": t.host,
+				"port": strconv.Itoa(t.port),
+			},
+		}
+	}
+
+	return protocolMap{
+		protocolTLS: contract.ProtocolProperties{
+			"host":               t.host,
+			"port":               strconv.Itoa(t.port),
+			"insecureSkipVerify": "true",
+		},
+	}
+}
+
+// expandTargets enumerates every host:port combination implied by subnets
+// and ports. A port equal to discoveryPortTLS is probed with TLS
+// (InsecureSkipVerify, since we don't yet know the reader's identity);
+// every other port is probed as plain TCP.
+func expandTargets(subnets []string, ports []int) ([]scanTarget, error) {
+	var targets []scanTarget
+	for _, subnet := range subnets {
+		ip, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, err
+		}
+
+		for addr := ip.Mask(ipNet.Mask); ipNet.Contains(addr); incIP(addr) {
+			host := addr.String()
+			for _, port := range ports {
+				targets = append(targets, scanTarget{
+					host: host,
+					port: port,
+					tls:  port == discoveryPortTLS,
+				})
+			}
+		}
+	}
+	return targets, nil
+}
+
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return
+		}
+	}
+}
+
+// probeAndPublish dials t, waits for the reader to announce a successful
+// connection, reads its capabilities, and - if it's not a duplicate of a
+// reader already seen on another NIC this scan - publishes it to d.deviceCh.
+// probeTimeout bounds this single host:port attempt (cfg.ProbeTimeout).
+func (d *Driver) probeAndPublish(ctx context.Context, t scanTarget, found *sync.Map, probeTimeout time.Duration) {
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	caps, err := d.probe(probeCtx, t)
+	if err != nil {
+		return
+	}
+
+	readerID := caps.readerID()
+	if readerID != "" {
+		if _, dup := found.LoadOrStore(readerID, struct{}{}); dup {
+			return
+		}
+	}
+
+	dev := dsModels.DiscoveredDevice{
+		Name:        "llrp-" + strings.ReplaceAll(t.host, ".", "-"),
+		Protocols:   t.protocols(),
+		Description: "auto-discovered LLRP reader",
+		Labels: []string{
+			"manufacturer:" + caps.manufacturer,
+			"model:" + caps.model,
+			"firmware:" + caps.firmware,
+			"gpioPorts:" + strconv.Itoa(int(caps.gpioPorts)),
+		},
+	}
+
+	d.lc.Info("discovered LLRP reader", "host", t.host, "port", t.port,
+		"manufacturer", caps.manufacturer, "model", caps.model, "readerID", readerID)
+
+	d.deviceCh <- []dsModels.DiscoveredDevice{dev}
+}
+
+// readerCaps is the subset of GetReaderCapabilitiesResponse Discover cares
+// about.
+type readerCaps struct {
+	manufacturer string
+	model        string
+	firmware     string
+	gpioPorts    uint16
+}
+
+// readerID returns a best-effort stand-in identity for de-duplication across
+// NICs. Real ReaderID is vendor-specific (e.g. a custom parameter) and isn't
+// surfaced by GetReaderCapabilitiesResponse's common fields, so this uses
+// manufacturer+model+firmware instead. That's a known limitation, not a true
+// ReaderID: two distinct readers of the same make, model, and firmware
+// version will be treated as the same reader if both are reachable in the
+// same scan.
+func (c readerCaps) readerID() string {
+	if c.manufacturer == "" && c.model == "" {
+		return ""
+	}
+	return c.manufacturer + "/" + c.model + "/" + c.firmware
+}
+
+// probe dials t, waits for the reader's initial ReaderEventNotification to
+// report a successful connection, then issues GetReaderCapabilities.
+func (d *Driver) probe(ctx context.Context, t scanTarget) (readerCaps, error) {
+	addr, err := net.ResolveTCPAddr("tcp", t.name())
+	if err != nil {
+		return readerCaps{}, err
+	}
+
+	var tlsCfg *tls.Config
+	if t.tls {
+		tlsCfg = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	timeout := 3 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	conn, err := dial(addr, tlsCfg, timeout)
+	if err != nil {
+		return readerCaps{}, err
+	}
+	defer conn.Close()
+
+	connected := make(chan struct{}, 1)
+	onNotification := llrp.MessageHandlerFunc(func(_ *llrp.Client, msg llrp.Message) {
+		if msg.Type() != llrp.MsgReaderEventNotification {
+			return
+		}
+
+		var notif llrp.ReaderEventNotification
+		if err := msg.UnmarshalTo(&notif); err != nil {
+			return
+		}
+
+		event := notif.ReaderEventNotificationData.ConnectionAttemptEvent
+		if event != nil && event.Status == llrp.ConnectionAttemptSuccess {
+			select {
+			case connected <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	c, err := llrp.NewClient(conn, llrp.WithMessageHandler(llrp.MsgReaderEventNotification, onNotification))
+	if err != nil {
+		return readerCaps{}, err
+	}
+	defer c.Close()
+
+	go c.Connect()
+
+	select {
+	case <-connected:
+	case <-ctx.Done():
+		return readerCaps{}, ctx.Err()
+	}
+
+	var resp llrp.GetReaderCapabilitiesResponse
+	if err := c.SendFor(ctx, &llrp.GetReaderCapabilities{}, &resp); err != nil {
+		return readerCaps{}, err
+	}
+
+	// GeneralDeviceCapabilities is itself an optional LLRP parameter - a
+	// reader is allowed to omit it entirely, so this has to be checked before
+	// touching any of its sub-fields, the same way GPIOCapabilities already
+	// is below.
+	general := resp.GeneralDeviceCapabilities
+	if general == nil {
+		return readerCaps{}, errors.New("reader capabilities response missing GeneralDeviceCapabilities")
+	}
+
+	// DeviceManufacturerName and ModelName are IANA enterprise/vendor codes
+	// per the LLRP spec, not strings; render them numerically since we don't
+	// carry a lookup table of vendor codes here.
+	caps := readerCaps{
+		manufacturer: strconv.FormatUint(uint64(general.DeviceManufacturerName), 10),
+		model:        strconv.FormatUint(uint64(general.ModelName), 10),
+		firmware:     general.ReaderFirmwareVersion,
+	}
+	if gpio := general.GPIOCapabilities; gpio != nil {
+		caps.gpioPorts = gpio.NumGPIs
+	}
+
+	return caps, nil
+}
@@ -0,0 +1,188 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	dsModels "github.com/edgexfoundry/device-sdk-go/pkg/models"
+)
+
+func TestExpandTargets(t *testing.T) {
+	targets, err := expandTargets([]string{"192.168.1.0/30"}, []int{discoveryPortPlain, discoveryPortTLS})
+	if err != nil {
+		t.Fatalf("expandTargets returned error: %v", err)
+	}
+
+	// /30 gives 4 addresses, times 2 ports each.
+	if len(targets) != 8 {
+		t.Fatalf("expected 8 targets, got %d: %+v", len(targets), targets)
+	}
+
+	for _, target := range targets {
+		wantTLS := target.port == discoveryPortTLS
+		if target.tls != wantTLS {
+			t.Errorf("target %+v: tls = %v, want %v", target, target.tls, wantTLS)
+		}
+	}
+
+	if _, err := expandTargets([]string{"not-a-cidr"}, []int{discoveryPortPlain}); err == nil {
+		t.Error("expected error for malformed CIDR, got nil")
+	}
+}
+
+func TestIncIP(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"192.168.1.1", "192.168.1.2"},
+		{"192.168.1.255", "192.168.2.0"},
+		{"192.168.255.255", "192.169.0.0"},
+	}
+
+	for _, c := range cases {
+		ip := net.ParseIP(c.in).To4()
+		incIP(ip)
+		if ip.String() != c.want {
+			t.Errorf("incIP(%s) = %s, want %s", c.in, ip, c.want)
+		}
+	}
+}
+
+func TestScanTargetProtocols(t *testing.T) {
+	plain := scanTarget{host: "192.168.1.10", port: discoveryPortPlain, tls: false}
+	p := plain.protocols()
+	tcpInfo := p[protocolTCP]
+	if tcpInfo == nil {
+		t.Fatalf("expected a %q entry for a non-TLS target, got %+v", protocolTCP, p)
+	}
+	if tcpInfo["host"] != plain.host || tcpInfo["port"] != "5084" {
+		t.Errorf("unexpected tcp entry: %+v", tcpInfo)
+	}
+	if p[protocolTLS] != nil {
+		t.Errorf("non-TLS target should not have a %q entry, got %+v", protocolTLS, p[protocolTLS])
+	}
+
+	secure := scanTarget{host: "192.168.1.10", port: discoveryPortTLS, tls: true}
+	p = secure.protocols()
+	tlsInfo := p[protocolTLS]
+	if tlsInfo == nil {
+		t.Fatalf("expected a %q entry for a TLS target, got %+v", protocolTLS, p)
+	}
+	if tlsInfo["host"] != secure.host || tlsInfo["port"] != "5085" {
+		t.Errorf("unexpected tls entry: %+v", tlsInfo)
+	}
+	if tlsInfo["insecureSkipVerify"] != "true" {
+		t.Errorf("expected insecureSkipVerify to be carried over from the probe, got %q", tlsInfo["insecureSkipVerify"])
+	}
+	if p[protocolTCP] != nil {
+		t.Errorf("TLS target should not have a %q entry, got %+v", protocolTCP, p[protocolTCP])
+	}
+}
+
+func TestDiscoverSkipsKnownAddr(t *testing.T) {
+	d := &Driver{
+		knownAddrs:   make(map[string]string),
+		addrByDevice: make(map[string]string),
+	}
+
+	target := scanTarget{host: "192.168.1.10", port: discoveryPortPlain}
+	if d.hasKnownAddr(target.name()) {
+		t.Fatalf("fresh driver should not already know %q", target.name())
+	}
+
+	d.rememberAddr("llrp-192-168-1-10", target.protocols())
+	if !d.hasKnownAddr(target.name()) {
+		t.Errorf("expected %q to be known once its device was added, so Discover would skip it", target.name())
+	}
+
+	d.forgetAddr("llrp-192-168-1-10")
+	if d.hasKnownAddr(target.name()) {
+		t.Errorf("expected %q to be forgotten once its device was removed", target.name())
+	}
+}
+
+func TestReaderCapsReaderID(t *testing.T) {
+	empty := readerCaps{}
+	if id := empty.readerID(); id != "" {
+		t.Errorf("expected empty readerID for zero-value caps, got %q", id)
+	}
+
+	a := readerCaps{manufacturer: "1", model: "2", firmware: "1.0"}
+	b := readerCaps{manufacturer: "1", model: "2", firmware: "1.0"}
+	if a.readerID() != b.readerID() {
+		t.Errorf("identical caps should produce identical readerIDs: %q != %q", a.readerID(), b.readerID())
+	}
+
+	// Known limitation: distinct readers sharing manufacturer/model/firmware
+	// collide, since no real ReaderID is available.
+	c := readerCaps{manufacturer: "1", model: "2", firmware: "1.0"}
+	if a.readerID() != c.readerID() {
+		t.Errorf("expected manufacturer/model/firmware collision, got distinct IDs %q and %q", a.readerID(), c.readerID())
+	}
+
+	d := readerCaps{manufacturer: "1", model: "2", firmware: "2.0"}
+	if a.readerID() == d.readerID() {
+		t.Errorf("readers with different firmware should not collide: %q", a.readerID())
+	}
+}
+
+// TestProbeAndPublishUsesConfiguredProbeTimeout is the regression test for
+// cfg.ProbeTimeout being computed by loadDiscoveryConfig and then dropped on
+// the floor: probeAndPublish used to hardcode 3*time.Second regardless of
+// what was configured. A reader that accepts the TCP connection but never
+// sends a ReaderEventNotification leaves probe() blocked on ctx.Done(), so
+// the probeTimeout passed in directly bounds how long probeAndPublish takes.
+func TestProbeAndPublishUsesConfiguredProbeTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			// Accept and hold the connection open without ever replying, so
+			// probe() blocks waiting for the reader's ReaderEventNotification.
+			go func() { <-make(chan struct{}); conn.Close() }()
+		}
+	}()
+
+	host, portStr, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to split listener addr: %v", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("failed to parse listener port: %v", err)
+	}
+	target := scanTarget{host: host, port: port}
+
+	d := newTestDriver()
+	d.deviceCh = make(chan []dsModels.DiscoveredDevice, 1)
+
+	const probeTimeout = 75 * time.Millisecond
+	var found sync.Map
+
+	start := time.Now()
+	d.probeAndPublish(context.Background(), target, &found, probeTimeout)
+	elapsed := time.Since(start)
+
+	// Give some slack for scheduling, but the configured 75ms timeout should
+	// bound the call, not the old hardcoded 3s default.
+	if elapsed > 1*time.Second {
+		t.Errorf("probeAndPublish took %v, expected it to respect the configured ProbeTimeout of %v", elapsed, probeTimeout)
+	}
+}
@@ -9,12 +9,10 @@ import (
 	"context"
 	"encoding"
 	"encoding/json"
-	"fmt"
 	"github.com/pkg/errors"
 	"github.impcloud.net/RSP-Inventory-Suite/device-llrp-go/internal/llrp"
-	"github.impcloud.net/RSP-Inventory-Suite/device-llrp-go/internal/retry"
 	"io/ioutil"
-	"net"
+	"reflect"
 	"sync"
 	"time"
 
@@ -23,6 +21,11 @@ import (
 	contract "github.com/edgexfoundry/go-mod-core-contracts/models"
 )
 
+const (
+	protocolTCP = "tcp"
+	protocolTLS = "tls"
+)
+
 const (
 	ServiceName string = "edgex-device-llrp"
 )
@@ -35,17 +38,68 @@ type Driver struct {
 	asyncCh  chan<- *dsModels.AsyncValues
 	deviceCh chan<- []dsModels.DiscoveredDevice
 
+	// clients holds the live Client for each connected device, and done
+	// holds the cancellation channel for that device's connectLoop.
+	// Both are guarded by clientsMapMu. A name is present in done from
+	// when ensureConnecting starts its connectLoop until that loop exits or
+	// removeClient/Stop cancels it, and in clients only while that
+	// connectLoop has published a successfully-dialed Client.
 	clients      map[string]*llrp.Client
+	done         map[string]chan struct{}
 	clientsMapMu sync.RWMutex
 
+	// knownAddrs and addrByDevice track the dial address (the same
+	// host:port form scanTarget.name() produces) registered for each managed
+	// device, so Discover can skip re-probing and re-publishing a reader
+	// that's already added. Both are guarded by clientsMapMu and kept in
+	// sync: knownAddrs is addr -> deviceName, addrByDevice is its reverse,
+	// used to clean up a device's old address when its protocols change.
+	knownAddrs   map[string]string
+	addrByDevice map[string]string
+
+	// protocolsByDevice holds the full protocolMap last used to (re)connect
+	// each device, guarded by clientsMapMu. reconnectOnAddrChange compares
+	// against this on every UpdateDevice instead of just addrByDevice's
+	// host:port, since a TLS-relevant field (a rotated caCertFile/
+	// clientCertFile/clientKeyFile/serverName, or insecureSkipVerify) can
+	// change without the dial address changing, and connectLoop would
+	// otherwise keep using the protocolMap captured in its closure.
+	protocolsByDevice map[string]protocolMap
+
+	// discoveryCancel, when non-nil, cancels the Discover() scan currently
+	// in progress; Stop uses it to abort in-flight probes.
+	discoveryCancel context.CancelFunc
+	discoveryMu     sync.Mutex
+
+	metrics metrics
+
+	// dialOnce performs a single dial attempt for connectLoop/dialWithBackoff.
+	// It's a field, defaulting to d.tryDial, rather than a direct method
+	// call, so tests can substitute a stub dial and exercise the connection
+	// lifecycle (backoff, the done-channel race, "still connecting" reads)
+	// without a real LLRP reader on the other end.
+	dialOnce func(name string, p protocolMap) (*llrp.Client, error)
+
+	// autoPollers holds the running AutoEvent pollers for each device,
+	// guarded by autoPollersMu. See autoevents.go.
+	autoPollers   map[string]*autoEventPoller
+	autoPollersMu sync.Mutex
+
 	svc ServiceWrapper
 }
 
 func NewProtocolDriver() dsModels.ProtocolDriver {
 	once.Do(func() {
 		driver = &Driver{
-			clients: make(map[string]*llrp.Client),
+			clients:           make(map[string]*llrp.Client),
+			done:              make(map[string]chan struct{}),
+			knownAddrs:        make(map[string]string),
+			addrByDevice:      make(map[string]string),
+			protocolsByDevice: make(map[string]protocolMap),
+			metrics:           newPromMetrics(),
+			autoPollers:       make(map[string]*autoEventPoller),
 		}
+		driver.dialOnce = driver.tryDial
 	})
 	return driver
 }
@@ -71,6 +125,8 @@ func (d *Driver) Initialize(lc logger.LoggingClient, asyncCh chan<- *dsModels.As
 	d.asyncCh = asyncCh
 	d.deviceCh = deviceCh
 
+	d.registerMetricsRoute()
+
 	go func() {
 		// hack: sleep to allow edgex time to finish loading cache and clients
 		time.Sleep(5 * time.Second)
@@ -100,47 +156,50 @@ const (
 	ActionDisable  = "Disable"
 	ActionStart    = "Start"
 	ActionStop     = "Stop"
+
+	// ActionRead and ActionSet label HandleReadCommands/HandleWriteCommands
+	// operations in metrics and logs that don't already have a more specific
+	// action (Enable/Start/Stop/Disable/Delete).
+	ActionRead = "Read"
+	ActionSet  = "Set"
+	ActionAdd  = "Add"
 )
 
 // HandleReadCommands triggers a protocol Read operation for the specified device.
 func (d *Driver) HandleReadCommands(devName string, p protocolMap, reqs []dsModels.CommandRequest) ([]*dsModels.CommandValue, error) {
-	d.lc.Debug(fmt.Sprintf("LLRP-Driver.HandleWriteCommands: "+
-		"device: %s protocols: %v reqs: %+v", devName, p, reqs))
+	dl := newDeviceLogger(d.lc, devName)
 
 	if len(reqs) == 0 {
 		return nil, errors.New("missing requests")
 	}
 
+	ctx, reqID := withRequestID(context.Background())
+	dl.Debug("handling read", "requestID", reqID, "resourceCount", len(reqs))
+
 	c, err := d.getClient(devName, p)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
 	defer cancel()
 
 	var responses = make([]*dsModels.CommandValue, len(reqs))
 	for i := range reqs {
-		var llrpReq llrp.Outgoing
-		var llrpResp llrp.Incoming
-
-		switch reqs[i].DeviceResourceName {
-		case ResourceReaderConfig:
-			llrpReq = &llrp.GetReaderConfig{}
-			llrpResp = &llrp.GetReaderConfigResponse{}
-		case ResourceReaderCap:
-			llrpReq = &llrp.GetReaderCapabilities{}
-			llrpResp = &llrp.GetReaderCapabilitiesResponse{}
-		case ResourceROSpec:
-			llrpReq = &llrp.GetROSpecs{}
-			llrpResp = &llrp.GetROSpecsResponse{}
-		case ResourceAccessSpec:
-			llrpReq = &llrp.GetAccessSpecs{}
-			llrpResp = &llrp.GetAccessSpecsResponse{}
+		llrpReq, llrpResp, err := resourceReadCommand(reqs[i].DeviceResourceName)
+		if err != nil {
+			return nil, err
 		}
 
-		if err := c.SendFor(ctx, llrpReq, llrpResp); err != nil {
-			return nil, err
+		start := time.Now()
+		sendErr := c.SendFor(ctx, llrpReq, llrpResp)
+		duration := time.Since(start)
+		d.metrics.observeCommand(devName, ActionRead, reqs[i].DeviceResourceName, sendErr, duration)
+		completedID, _ := requestIDFrom(ctx)
+		dl.Debug("read complete", "requestID", completedID, "resource", reqs[i].DeviceResourceName,
+			"duration", duration, "success", sendErr == nil)
+		if sendErr != nil {
+			return nil, sendErr
 		}
 
 		respData, err := json.Marshal(llrpResp)
@@ -155,18 +214,39 @@ func (d *Driver) HandleReadCommands(devName string, p protocolMap, reqs []dsMode
 	return responses, nil
 }
 
+// resourceReadCommand maps a readable device resource name to the LLRP
+// request/response pair that satisfies it. It's the single source of truth
+// for that mapping, shared by HandleReadCommands and AutoEvents polling, so
+// a new readable resource only needs to be taught here once.
+func resourceReadCommand(resource string) (llrp.Outgoing, llrp.Incoming, error) {
+	switch resource {
+	case ResourceReaderConfig:
+		return &llrp.GetReaderConfig{}, &llrp.GetReaderConfigResponse{}, nil
+	case ResourceReaderCap:
+		return &llrp.GetReaderCapabilities{}, &llrp.GetReaderCapabilitiesResponse{}, nil
+	case ResourceROSpec:
+		return &llrp.GetROSpecs{}, &llrp.GetROSpecsResponse{}, nil
+	case ResourceAccessSpec:
+		return &llrp.GetAccessSpecs{}, &llrp.GetAccessSpecsResponse{}, nil
+	default:
+		return nil, nil, errors.Errorf("unsupported read resource %q", resource)
+	}
+}
+
 // HandleWriteCommands passes a slice of CommandRequest struct each representing
 // a ResourceOperation for a specific device resource.
 // Since the commands are actuation commands, params provide parameters for the individual
 // command.
 func (d *Driver) HandleWriteCommands(devName string, p protocolMap, reqs []dsModels.CommandRequest, params []*dsModels.CommandValue) error {
-	d.lc.Debug(fmt.Sprintf("LLRP-Driver.HandleWriteCommands: "+
-		"device: %s protocols: %v reqs: %+v", devName, p, reqs))
+	dl := newDeviceLogger(d.lc, devName)
 
 	if len(reqs) == 0 {
 		return errors.New("missing requests")
 	}
 
+	ctx, reqID := withRequestID(context.Background())
+	dl.Debug("handling write", "requestID", reqID, "resource", reqs[0].DeviceResourceName, "paramCount", len(params))
+
 	c, err := d.getClient(devName, p)
 	if err != nil {
 		return err
@@ -207,13 +287,14 @@ func (d *Driver) HandleWriteCommands(devName string, p protocolMap, reqs []dsMod
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
 	defer cancel()
 
 	var llrpReq llrp.Outgoing  // the message to send
 	var llrpResp llrp.Incoming // the expected response
 	var reqData []byte         // incoming JSON request data, if present
 	var dataTarget interface{} // used if the reqData in a subfield of the llrpReq
+	var cmdAction string       // the action label recorded in metrics/logs
 
 	switch reqs[0].DeviceResourceName {
 	case ResourceReaderConfig:
@@ -222,6 +303,7 @@ func (d *Driver) HandleWriteCommands(devName string, p protocolMap, reqs []dsMod
 			return err
 		}
 
+		cmdAction = ActionSet
 		reqData = []byte(data)
 		llrpReq = &llrp.SetReaderConfig{}
 		llrpResp = &llrp.SetReaderConfigResponse{}
@@ -231,6 +313,7 @@ func (d *Driver) HandleWriteCommands(devName string, p protocolMap, reqs []dsMod
 			return err
 		}
 
+		cmdAction = ActionAdd
 		reqData = []byte(data)
 
 		addSpec := llrp.AddROSpec{}
@@ -247,6 +330,7 @@ func (d *Driver) HandleWriteCommands(devName string, p protocolMap, reqs []dsMod
 			return err
 		}
 
+		cmdAction = action
 		roID, err := getUint32Param(action+ResourceROSpec, 0, ResourceROSpecID)
 		if err != nil {
 			return err
@@ -278,6 +362,7 @@ func (d *Driver) HandleWriteCommands(devName string, p protocolMap, reqs []dsMod
 		}
 
 		action := reqs[1].DeviceResourceName
+		cmdAction = action
 
 		asID, err := getUint32Param(action+ResourceAccessSpecID, 0, ResourceAccessSpecID)
 		if err != nil {
@@ -312,14 +397,21 @@ func (d *Driver) HandleWriteCommands(devName string, p protocolMap, reqs []dsMod
 	}
 
 	// SendFor will handle turning ErrorMessages and failing LLRPStatuses into errors.
-	if err := c.SendFor(ctx, llrpReq, llrpResp); err != nil {
-		return err
+	start := time.Now()
+	sendErr := c.SendFor(ctx, llrpReq, llrpResp)
+	duration := time.Since(start)
+	d.metrics.observeCommand(devName, cmdAction, reqs[0].DeviceResourceName, sendErr, duration)
+	completedID, _ := requestIDFrom(ctx)
+	dl.Debug("write complete", "requestID", completedID, "action", cmdAction,
+		"resource", reqs[0].DeviceResourceName, "duration", duration, "success", sendErr == nil)
+	if sendErr != nil {
+		return sendErr
 	}
 
 	go func(resName, devName string, resp llrp.Incoming) {
 		respData, err := json.Marshal(resp)
 		if err != nil {
-			d.lc.Error("failed to marshal response", "message", resName, "error", err)
+			dl.Error("failed to marshal response", "message", resName, "error", err)
 			return
 		}
 
@@ -345,17 +437,35 @@ func (d *Driver) Stop(force bool) error {
 	}
 	d.lc.Debug("LLRP-Driver.Stop called", "force", force)
 
+	d.discoveryMu.Lock()
+	if d.discoveryCancel != nil {
+		d.discoveryCancel()
+	}
+	d.discoveryMu.Unlock()
+
+	d.stopAllAutoEvents()
+
 	d.clientsMapMu.Lock()
-	defer d.clientsMapMu.Unlock()
+	clients := d.clients
+	dones := d.done
+	d.clients = make(map[string]*llrp.Client)
+	d.done = make(map[string]chan struct{})
+	d.clientsMapMu.Unlock()
+
+	// Closing done first unblocks any connectLoop still dialing, so it
+	// doesn't race a fresh AddDevice for the same name.
+	for _, done := range dones {
+		close(done)
+	}
 
 	var wg *sync.WaitGroup
 	if !force {
 		wg = new(sync.WaitGroup)
-		wg.Add(len(d.clients))
+		wg.Add(len(clients))
 		defer wg.Wait()
 	}
 
-	for _, c := range d.clients {
+	for _, c := range clients {
 		go func(c *llrp.Client) {
 			d.stopClient(c, force)
 			if !force {
@@ -364,41 +474,137 @@ func (d *Driver) Stop(force bool) error {
 		}(c)
 	}
 
-	d.clients = make(map[string]*llrp.Client)
 	return nil
 }
 
 // AddDevice is a callback function that is invoked
 // when a new Device associated with this Device Service is added
 func (d *Driver) AddDevice(deviceName string, protocols protocolMap, adminState contract.AdminState) error {
-	d.lc.Debug(fmt.Sprintf("Adding new device: %s protocols: %v adminState: %v",
-		deviceName, protocols, adminState))
-	_, err := d.getClient(deviceName, protocols)
-	return err
+	newDeviceLogger(d.lc, deviceName).Debug("adding device", "protocols", protocols, "adminState", adminState)
+	d.rememberAddr(deviceName, protocols)
+	d.rememberProtocols(deviceName, protocols)
+	// ensureConnecting only kicks off connectLoop; it doesn't wait for a
+	// dial to resolve, so a slow or unreachable reader can't stall AddDevice.
+	d.ensureConnecting(deviceName, protocols)
+	d.startAutoEvents(deviceName)
+	return nil
 }
 
 // UpdateDevice is a callback function that is invoked
 // when a Device associated with this Device Service is updated
 func (d *Driver) UpdateDevice(deviceName string, protocols protocolMap, adminState contract.AdminState) error {
-	d.lc.Debug(fmt.Sprintf("Updating device: %s protocols: %v adminState: %v",
-		deviceName, protocols, adminState))
+	newDeviceLogger(d.lc, deviceName).Debug("updating device", "protocols", protocols, "adminState", adminState)
+	d.reconnectOnAddrChange(deviceName, protocols)
+	// startAutoEvents stops any poller already running for deviceName before
+	// starting fresh ones, so this also picks up AutoEvents config changes.
+	d.startAutoEvents(deviceName)
 	return nil
 }
 
+// reconnectOnAddrChange records deviceName's (possibly new) protocols and,
+// if anything relevant to the connection changed, forces a reconnect.
+// connectLoop captured the protocolMap it was started with in its closure,
+// so without this a device whose address or TLS config changed (new IP, new
+// port, rotated caCertFile/clientCertFile/clientKeyFile/serverName, toggled
+// insecureSkipVerify) would keep dialing with the old config until an
+// unrelated connection drop happened to pick up the new one. The whole
+// protocolMap is compared, not just host:port, since a cert/path rotation
+// alone must also force a reconnect.
+func (d *Driver) reconnectOnAddrChange(deviceName string, protocols protocolMap) {
+	d.clientsMapMu.RLock()
+	oldProtocols, hadProtocols := d.protocolsByDevice[deviceName]
+	d.clientsMapMu.RUnlock()
+
+	d.rememberAddr(deviceName, protocols)
+	d.rememberProtocols(deviceName, protocols)
+
+	if hadProtocols && reflect.DeepEqual(oldProtocols, protocols) {
+		return
+	}
+
+	newDeviceLogger(d.lc, deviceName).Debug("device protocols changed, forcing reconnect",
+		"oldProtocols", oldProtocols, "newProtocols", protocols)
+	d.removeClient(deviceName, false)
+	d.ensureConnecting(deviceName, protocols)
+}
+
 // RemoveDevice is a callback function that is invoked
 // when a Device associated with this Device Service is removed
 func (d *Driver) RemoveDevice(deviceName string, p protocolMap) error {
-	d.lc.Debug(fmt.Sprintf("Removing device: %s protocols: %v", deviceName, p))
+	newDeviceLogger(d.lc, deviceName).Debug("removing device", "protocols", p)
+	d.stopAutoEvents(deviceName)
 	d.removeClient(deviceName, false)
+	d.forgetAddr(deviceName)
+	d.forgetProtocols(deviceName)
 	return nil
 }
 
+// rememberAddr records deviceName's dial address, extracted from protocols,
+// in knownAddrs so Discover doesn't re-probe and re-publish a reader that's
+// already managed. It replaces any address previously recorded for
+// deviceName, e.g. after UpdateDevice points it somewhere new.
+func (d *Driver) rememberAddr(deviceName string, protocols protocolMap) {
+	addr, ok := addrFromProtocols(protocols)
+
+	d.clientsMapMu.Lock()
+	defer d.clientsMapMu.Unlock()
+
+	if old, had := d.addrByDevice[deviceName]; had {
+		delete(d.knownAddrs, old)
+	}
+	if !ok {
+		delete(d.addrByDevice, deviceName)
+		return
+	}
+	d.knownAddrs[addr] = deviceName
+	d.addrByDevice[deviceName] = addr
+}
+
+// forgetAddr removes deviceName's recorded address, if any.
+func (d *Driver) forgetAddr(deviceName string) {
+	d.clientsMapMu.Lock()
+	defer d.clientsMapMu.Unlock()
+
+	if old, had := d.addrByDevice[deviceName]; had {
+		delete(d.knownAddrs, old)
+		delete(d.addrByDevice, deviceName)
+	}
+}
+
+// rememberProtocols records the full protocolMap deviceName was last
+// (re)connected with, so a later UpdateDevice can detect any relevant change
+// - not just the dial address - via reconnectOnAddrChange.
+func (d *Driver) rememberProtocols(deviceName string, protocols protocolMap) {
+	d.clientsMapMu.Lock()
+	defer d.clientsMapMu.Unlock()
+	d.protocolsByDevice[deviceName] = protocols
+}
+
+// forgetProtocols removes deviceName's recorded protocolMap, if any.
+func (d *Driver) forgetProtocols(deviceName string) {
+	d.clientsMapMu.Lock()
+	defer d.clientsMapMu.Unlock()
+	delete(d.protocolsByDevice, deviceName)
+}
+
+// hasKnownAddr reports whether addr - in the same host:port form
+// scanTarget.name() produces - is already registered to a managed device,
+// so Discover can skip probing and re-publishing it.
+func (d *Driver) hasKnownAddr(addr string) bool {
+	d.clientsMapMu.RLock()
+	defer d.clientsMapMu.RUnlock()
+	_, ok := d.knownAddrs[addr]
+	return ok
+}
+
 // handleAsyncMessages forwards JSON-marshaled messages to EdgeX.
 //
 // Note that the message types that end up here depend on the subscriptions
 // when the Client is created, so if you want to add another,
 // you'll need to wire up the handler in the getClient code.
 func (d *Driver) handleAsyncMessages(c *llrp.Client, msg llrp.Message) {
+	dl := newDeviceLogger(d.lc, c.Name)
+
 	var resourceName string
 	var event encoding.BinaryUnmarshaler
 
@@ -414,13 +620,20 @@ func (d *Driver) handleAsyncMessages(c *llrp.Client, msg llrp.Message) {
 	}
 
 	if err := msg.UnmarshalTo(event); err != nil {
-		d.lc.Error("failed to unmarshal async event from LLRP", "error", err.Error())
+		dl.Error("failed to unmarshal async event from LLRP", "messageType", msg.Type(), "error", err.Error())
 		return
 	}
 
+	tagCount := 0
+	if report, ok := event.(*llrp.ROAccessReport); ok {
+		tagCount = len(report.TagReportData)
+	}
+	d.metrics.observeAsyncMessage(c.Name, msg.Type(), tagCount)
+	dl.Debug("handling async event", "resource", resourceName, "tagReportCount", tagCount)
+
 	data, err := json.Marshal(event)
 	if err != nil {
-		d.lc.Error("failed to marshal async event to JSON", "error", err.Error())
+		dl.Error("failed to marshal async event to JSON", "resource", resourceName, "error", err.Error())
 		return
 	}
 
@@ -432,160 +645,6 @@ func (d *Driver) handleAsyncMessages(c *llrp.Client, msg llrp.Message) {
 	}
 }
 
-// getOrCreate returns a Client, creating one if needed.
-//
-// If a Client with this name already exists, it returns it.
-// Otherwise, calls the createNew function to get a new Client,
-// which it adds to the map and then returns.
-func (d *Driver) getClient(name string, p protocolMap) (*llrp.Client, error) {
-	// Try with just a read lock.
-	d.clientsMapMu.RLock()
-	c, ok := d.clients[name]
-	d.clientsMapMu.RUnlock()
-	if ok {
-		return c, nil
-	}
-
-	addr, err := getAddr(p)
-	if err != nil {
-		return nil, err
-	}
-}
-
-func (d *Driver) createClient(name string, addr net.Addr) (*llrp.Client, error) {
-	// It's important it holds the lock while creating a new Client.
-	// If two requests arrive at about the same time and target the same device,
-	// one will block waiting for the lock and the other will create and add a Client.
-	// If both requests created a new Client,
-	// at most only one would succeed in connecting,
-	// so we want to only create one Client, add it to the map,
-	// and return that Client to all callers requesting it.
-	// However,
-	// After adding the Client, it unlocks, then attempts to connect
-	// (really the connect can happen before unlock, since it happens in a goroutine).
-	// Once it unlocks, the other request gains the lock and must recheck the map.
-	// It will retrieve the freshly created Client, and thus return it.
-	// Both requests will attempt their Send,
-	// which will block until the Client connects (or fails to do so),
-	// or until they cancel their Send attempt (e.g., timing out).
-	d.clientsMapMu.Lock()
-	defer d.clientsMapMu.Unlock()
-	c, ok := d.clients[name]
-	if ok {
-		return c, nil
-	}
-
-	// At this point, a single request is creating the Client,
-	// though others may be blocked waiting to check the clients map.
-	// The goal is to create a Client quickly put it in the map, and return it.
-	// After returning (read: in a new goroutine), we manage its connection.
-	// In the meantime, multiple callers needing a connection to the same reader
-	// will get back a valid Client on which they can Send methods,
-	// though those Send methods will block until either the Client is connected
-	// or the connection fails (in which case they'll correctly see the failure).
-	// Requests for other Client connections will be blocked for a short time
-	// while the
-
-	tryDial := func() (*llrp.Client, error) {
-		conn, err := net.DialTimeout(addr.Network(), addr.String(), time.Second*30)
-		if err != nil {
-			return nil, err
-		}
-
-		toEdgex := llrp.MessageHandlerFunc(d.handleAsyncMessages)
-
-		return llrp.NewClient(conn,
-			llrp.WithName(name),
-			llrp.WithLogger(&edgexLLRPClientLogger{devName: name, lc: d.lc}),
-			llrp.WithMessageHandler(llrp.MsgROAccessReport, toEdgex),
-			llrp.WithMessageHandler(llrp.MsgReaderEventNotification, toEdgex),
-		)
-	}
-
-	c, err = tryDial()
-	if err != nil {
-		return nil, err
-	}
-
-	go func() {
-		var c *llrp.Client
-		err := retry.Slow.RetrySome(retry.Forever, func() (recoverable bool, err error) {
-			c, err = tryDial()
-			neterr, ok := err.(net.Error)
-			recoverable = ok && neterr.Temporary()
-			return
-		})
-
-		if err != nil {
-		}
-
-		// blocks until the Client is closed
-		err = c.Connect()
-		d.removeClient(c.Name, false)
-		if err == nil || errors.Is(err, llrp.ErrClientClosed) {
-			return
-		}
-
-		d.lc.Error(err.Error())
-
-		// client closed without call to Close or Shutdown;
-		// try to reconnect
-		retry.Slow.RetrySome(retry.Forever, func() (recoverable bool, err error) {
-			if
-		})
-	}()
-
-	d.clients[name] = c
-	return c, nil
-}
-
-// removeClient deletes a Client from the clients map.
-func (d *Driver) removeClient(deviceName string, force bool) {
-	d.clientsMapMu.Lock()
-	defer d.clientsMapMu.Unlock()
-
-	if c, ok := d.clients[deviceName]; ok {
-		delete(d.clients, deviceName)
-		go d.stopClient(c, force)
-	}
-}
-
-func (d *Driver) stopClient(c *llrp.Client, force bool) {
-	if !force {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-		err := c.Shutdown(ctx)
-		if err == nil || errors.Is(err, llrp.ErrClientClosed) {
-			return
-		}
-		d.lc.Error("error attempting graceful client shutdown", "error", err.Error())
-	}
-
-	if err := c.Close(); err != nil && !errors.Is(err, llrp.ErrClientClosed) {
-		d.lc.Error("error attempting forceful client shutdown", "error", err.Error())
-	}
-}
-
-// getAddr extracts an address from a protocol mapping.
-//
-// It expects the map to have {"tcp": {"host": "<ip>", "port": "<port>"}}.
-func getAddr(protocols protocolMap) (net.Addr, error) {
-	tcpInfo := protocols["tcp"]
-	if tcpInfo == nil {
-		return nil, errors.New("missing tcp protocol")
-	}
-
-	host := tcpInfo["host"]
-	port := tcpInfo["port"]
-	if host == "" || port == "" {
-		return nil, errors.Errorf("tcp missing host or port (%q, %q)", host, port)
-	}
-
-	addr, err := net.ResolveTCPAddr("tcp", host+":"+port)
-	return addr, errors.Wrapf(err,
-		"unable to create addr for tcp protocol (%q, %q)", host, port)
-}
-
 func (d *Driver) addProvisionWatcher() error {
 	var provisionWatcher contract.ProvisionWatcher
 	data, err := ioutil.ReadFile("res/provisionwatcher.json")
@@ -607,9 +666,3 @@ func (d *Driver) addProvisionWatcher() error {
 
 	return nil
 }
-
-func (d *Driver) Discover() {
-	d.lc.Info("*** Discover was called ***")
-	d.deviceCh <- autoDiscover()
-	d.lc.Info("scanning complete")
-}
@@ -0,0 +1,70 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+)
+
+// deviceLogger wraps a LoggingClient, auto-injecting "device" into every
+// call so individual log sites in driver.go don't have to repeat it.
+type deviceLogger struct {
+	lc     logger.LoggingClient
+	device string
+}
+
+func newDeviceLogger(lc logger.LoggingClient, device string) *deviceLogger {
+	return &deviceLogger{lc: lc, device: device}
+}
+
+func (l *deviceLogger) with(args []interface{}) []interface{} {
+	return append([]interface{}{"device", l.device}, args...)
+}
+
+func (l *deviceLogger) Debug(msg string, args ...interface{}) {
+	l.lc.Debug(msg, l.with(args)...)
+}
+
+func (l *deviceLogger) Info(msg string, args ...interface{}) {
+	l.lc.Info(msg, l.with(args)...)
+}
+
+func (l *deviceLogger) Warn(msg string, args ...interface{}) {
+	l.lc.Warn(msg, l.with(args)...)
+}
+
+func (l *deviceLogger) Error(msg string, args ...interface{}) {
+	l.lc.Error(msg, l.with(args)...)
+}
+
+// edgexLLRPClientLogger adapts a LoggingClient to the logging interface
+// llrp.Client expects (see llrp.WithLogger), auto-injecting the owning
+// device's name into every call the way deviceLogger does for driver.go's
+// own log sites.
+type edgexLLRPClientLogger struct {
+	devName string
+	lc      logger.LoggingClient
+}
+
+func (l *edgexLLRPClientLogger) with(kvs []interface{}) []interface{} {
+	return append([]interface{}{"device", l.devName}, kvs...)
+}
+
+func (l *edgexLLRPClientLogger) Debug(msg string, kvs ...interface{}) {
+	l.lc.Debug(msg, l.with(kvs)...)
+}
+
+func (l *edgexLLRPClientLogger) Info(msg string, kvs ...interface{}) {
+	l.lc.Info(msg, l.with(kvs)...)
+}
+
+func (l *edgexLLRPClientLogger) Warn(msg string, kvs ...interface{}) {
+	l.lc.Warn(msg, l.with(kvs)...)
+}
+
+func (l *edgexLLRPClientLogger) Error(msg string, kvs ...interface{}) {
+	l.lc.Error(msg, l.with(kvs)...)
+}
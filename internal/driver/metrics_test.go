@@ -0,0 +1,47 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import "testing"
+
+func TestConnStateString(t *testing.T) {
+	cases := map[connState]string{
+		connDialing:      "dialing",
+		connConnected:    "connected",
+		connReconnecting: "reconnecting",
+		connClosed:       "closed",
+		connState(99):    "unknown",
+	}
+
+	for state, want := range cases {
+		if got := state.String(); got != want {
+			t.Errorf("connState(%d).String() = %q, want %q", state, got, want)
+		}
+	}
+}
+
+func TestPromMetricsSetConnState(t *testing.T) {
+	m := newPromMetrics()
+
+	m.setConnState("reader-1", connDialing)
+	if got := m.connStates["reader-1"]; got != connDialing {
+		t.Fatalf("connStates[reader-1] = %v, want %v", got, connDialing)
+	}
+
+	m.setConnState("reader-1", connConnected)
+	if got := m.connStates["reader-1"]; got != connConnected {
+		t.Fatalf("connStates[reader-1] = %v, want %v", got, connConnected)
+	}
+
+	// A second device's state is tracked independently.
+	m.setConnState("reader-2", connReconnecting)
+	if got := m.connStates["reader-2"]; got != connReconnecting {
+		t.Fatalf("connStates[reader-2] = %v, want %v", got, connReconnecting)
+	}
+	if got := m.connStates["reader-1"]; got != connConnected {
+		t.Fatalf("reader-1's state changed after setting reader-2's: got %v", got)
+	}
+}
@@ -0,0 +1,45 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type requestIDKey struct{}
+
+// withRequestID returns a context carrying a newly-generated requestID, so a
+// single command's lifecycle - received, sent, completed - can be correlated
+// across the log lines this package emits for it. The ID rides along on ctx
+// as it's passed to llrp.Client.SendFor, but nothing in this package can make
+// that call's own internal logging (encode/decode, wire I/O) read it back;
+// doing that would require the llrp package to expose its own context key and
+// accessor, which it doesn't today.
+func withRequestID(ctx context.Context) (context.Context, string) {
+	id := newRequestID()
+	return context.WithValue(ctx, requestIDKey{}, id), id
+}
+
+// requestIDFrom extracts the requestID stashed by withRequestID, if any. The
+// completion log lines in HandleReadCommands and HandleWriteCommands fetch it
+// back through ctx after ctx has been re-wrapped by context.WithTimeout; a
+// value stored with context.WithValue stays visible through such wrapping.
+func requestIDFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+func newRequestID() string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		// crypto/rand failing would mean the system's entropy source is
+		// broken; a zero ID still lets the rest of the request proceed.
+		return "00000000"
+	}
+	return hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,131 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base32"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// newTLSConfig builds a *tls.Config from the fields of a device's "tls"
+// protocol entry:
+//
+//	{"tls": {
+//		"host": "...", "port": "...",
+//		"caCertFile": "...",       // optional; defaults to the system pool
+//		"clientCertFile": "...",   // optional; enables mutual auth
+//		"clientKeyFile": "...",    // required if clientCertFile is set
+//		"serverName": "...",       // optional; defaults to the host
+//		"insecureSkipVerify": "true"|"false",
+//	}}
+//
+// Certificate and key files are read fresh on every call so that rotating
+// them on disk takes effect on the next dial or reconnect attempt, without
+// requiring a service restart.
+func newTLSConfig(tlsInfo map[string]string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: tlsInfo["serverName"],
+	}
+
+	if skip := tlsInfo["insecureSkipVerify"]; skip != "" {
+		insecure, err := strconv.ParseBool(skip)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid insecureSkipVerify value %q", skip)
+		}
+		cfg.InsecureSkipVerify = insecure
+	}
+
+	if cfg.ServerName == "" {
+		if host, _, err := net.SplitHostPort(tlsInfo["host"] + ":" + tlsInfo["port"]); err == nil {
+			cfg.ServerName = host
+		} else {
+			cfg.ServerName = tlsInfo["host"]
+		}
+	}
+
+	if caCertFile := tlsInfo["caCertFile"]; caCertFile != "" {
+		pemBytes, err := ioutil.ReadFile(caCertFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read caCertFile %q", caCertFile)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, errors.Errorf("no certificates found in caCertFile %q", caCertFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	clientCertFile := tlsInfo["clientCertFile"]
+	clientKeyFile := tlsInfo["clientKeyFile"]
+	if clientCertFile != "" || clientKeyFile != "" {
+		if clientCertFile == "" || clientKeyFile == "" {
+			return nil, errors.New("clientCertFile and clientKeyFile must both be set for mutual TLS")
+		}
+
+		cert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load client certificate/key pair")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// dial opens a connection to addr, using tls.Dial when tlsCfg is non-nil and
+// a plain net.DialTimeout otherwise.
+func dial(addr net.Addr, tlsCfg *tls.Config, timeout time.Duration) (net.Conn, error) {
+	if tlsCfg == nil {
+		return net.DialTimeout(addr.Network(), addr.String(), timeout)
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	return tls.DialWithDialer(dialer, addr.Network(), addr.String(), tlsCfg)
+}
+
+// peerFingerprint computes a stable, human-comparable identity for the peer
+// presented during a TLS handshake, so operators can pin a reader by
+// identity even as its IP changes (e.g., across DHCP renewals).
+//
+// It's a SHA-256 digest of the leaf certificate's raw DER bytes, rendered in
+// groups the way Syncthing renders its device IDs, to make fingerprints easy
+// to read aloud or diff at a glance.
+func peerFingerprint(conn *tls.Conn) (string, error) {
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return "", errors.New("no peer certificate presented")
+	}
+
+	return fingerprint(state.PeerCertificates[0]), nil
+}
+
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	encoded := strings.ToUpper(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:]))
+
+	var b strings.Builder
+	for i := 0; i < len(encoded); i += 7 {
+		end := i + 7
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(encoded[i:end])
+	}
+	return b.String()
+}
@@ -0,0 +1,116 @@
+//
+// Copyright (C) 2020 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTLSConfigServerName(t *testing.T) {
+	cfg, err := newTLSConfig(map[string]string{"host": "reader.example.com", "port": "5085"})
+	if err != nil {
+		t.Fatalf("newTLSConfig returned error: %v", err)
+	}
+	if cfg.ServerName != "reader.example.com" {
+		t.Errorf("ServerName = %q, want %q", cfg.ServerName, "reader.example.com")
+	}
+
+	cfg, err = newTLSConfig(map[string]string{
+		"host": "reader.example.com", "port": "5085", "serverName": "override.example.com",
+	})
+	if err != nil {
+		t.Fatalf("newTLSConfig returned error: %v", err)
+	}
+	if cfg.ServerName != "override.example.com" {
+		t.Errorf("explicit serverName should win: ServerName = %q", cfg.ServerName)
+	}
+}
+
+func TestNewTLSConfigInsecureSkipVerify(t *testing.T) {
+	cfg, err := newTLSConfig(map[string]string{
+		"host": "reader.example.com", "port": "5085", "insecureSkipVerify": "true",
+	})
+	if err != nil {
+		t.Fatalf("newTLSConfig returned error: %v", err)
+	}
+	if !cfg.InsecureSkipVerify {
+		t.Error("expected InsecureSkipVerify to be true")
+	}
+
+	if _, err := newTLSConfig(map[string]string{
+		"host": "reader.example.com", "port": "5085", "insecureSkipVerify": "not-a-bool",
+	}); err == nil {
+		t.Error("expected an error for a malformed insecureSkipVerify value")
+	}
+}
+
+func TestNewTLSConfigMutualTLSRequiresBoth(t *testing.T) {
+	if _, err := newTLSConfig(map[string]string{
+		"host": "reader.example.com", "port": "5085", "clientCertFile": "cert.pem",
+	}); err == nil {
+		t.Error("expected an error when clientKeyFile is missing")
+	}
+
+	if _, err := newTLSConfig(map[string]string{
+		"host": "reader.example.com", "port": "5085", "clientKeyFile": "key.pem",
+	}); err == nil {
+		t.Error("expected an error when clientCertFile is missing")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	cert := selfSignedCert(t)
+
+	fp := fingerprint(cert)
+	if fp == "" {
+		t.Fatal("fingerprint returned empty string")
+	}
+	if fingerprint(cert) != fp {
+		t.Error("fingerprint should be deterministic for the same certificate")
+	}
+	if !strings.Contains(fp, "-") {
+		t.Errorf("expected fingerprint to be grouped with separators, got %q", fp)
+	}
+
+	other := selfSignedCert(t)
+	if fingerprint(other) == fp {
+		t.Error("distinct certificates should not share a fingerprint")
+	}
+}
+
+func selfSignedCert(t *testing.T) *x509.Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-reader"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unable to create certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unable to parse certificate: %v", err)
+	}
+	return cert
+}